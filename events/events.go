@@ -0,0 +1,371 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events implements a small pod/app/image lifecycle event bus.
+//
+// Every event is recorded to a local append-only ring buffer file under the
+// data dir, and, when available, to the systemd journal so that 'journalctl'
+// and other journald consumers can see rkt lifecycle events alongside the
+// rest of the system log. Subscribers (the ListenEvents gRPC handler) tail
+// whichever source is available.
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-systemd/journal"
+	"github.com/coreos/go-systemd/sdjournal"
+)
+
+// Type identifies the kind of lifecycle event being recorded.
+type Type string
+
+const (
+	TypePodCreated    Type = "pod-created"
+	TypePodPrepared   Type = "pod-prepared"
+	TypePodStarted    Type = "pod-started"
+	TypePodExited     Type = "pod-exited"
+	TypePodGarbage    Type = "pod-garbage-collected"
+	TypeAppStarted    Type = "app-started"
+	TypeAppExited     Type = "app-exited"
+	TypeImageImported Type = "image-imported"
+	TypeImageRemoved  Type = "image-removed"
+)
+
+// journalIdentifier is used as SYSLOG_IDENTIFIER for events sent to journald.
+const journalIdentifier = "rkt"
+
+// ringFileName is the base name of the ring buffer file under the data dir.
+const ringFileName = "events.log"
+
+// maxRingFileSize is the size at which the ring buffer file is rotated.
+const maxRingFileSize = 10 * 1024 * 1024 // 10MiB
+
+// maxRingFileBackups is the number of rotated ring buffer files to keep
+// around (events.log.1, events.log.2, ...).
+const maxRingFileBackups = 4
+
+// Event is a single pod/app/image lifecycle event.
+type Event struct {
+	Type    Type              `json:"type"`
+	Time    time.Time         `json:"time"`
+	PodUUID string            `json:"pod_uuid,omitempty"`
+	AppName string            `json:"app_name,omitempty"`
+	ImageID string            `json:"image_id,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Recorder records lifecycle events to the on-disk ring buffer and, best
+// effort, to the systemd journal.
+type Recorder struct {
+	mu      sync.Mutex
+	dataDir string
+	file    *os.File
+	size    int64
+}
+
+// NewRecorder returns a Recorder that writes its ring buffer under dataDir.
+func NewRecorder(dataDir string) (*Recorder, error) {
+	r := &Recorder{dataDir: dataDir}
+	if err := r.openRingFile(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) ringPath() string {
+	return filepath.Join(r.dataDir, ringFileName)
+}
+
+func (r *Recorder) openRingFile() error {
+	f, err := os.OpenFile(r.ringPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("events: failed to open ring buffer file: %v", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("events: failed to stat ring buffer file: %v", err)
+	}
+	r.file = f
+	r.size = fi.Size()
+	return nil
+}
+
+// rotate renames the current ring buffer file out of the way, keeping up to
+// maxRingFileBackups previous generations, and starts a fresh one.
+func (r *Recorder) rotate() error {
+	if r.file != nil {
+		r.file.Close()
+	}
+
+	for i := maxRingFileBackups - 1; i >= 1; i-- {
+		src := fmt.Sprintf("%s.%d", r.ringPath(), i)
+		dst := fmt.Sprintf("%s.%d", r.ringPath(), i+1)
+		if _, err := os.Stat(src); err == nil {
+			os.Rename(src, dst)
+		}
+	}
+	if _, err := os.Stat(r.ringPath()); err == nil {
+		os.Rename(r.ringPath(), r.ringPath()+".1")
+	}
+
+	return r.openRingFile()
+}
+
+// Emit records ev to the ring buffer and, if journald is reachable, sends it
+// there as well with typed fields so it can be queried with
+// 'journalctl -t rkt EVENT_TYPE=...'.
+func (r *Recorder) Emit(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("events: failed to marshal event: %v", err)
+	}
+	line = append(line, '\n')
+
+	if r.size+int64(len(line)) > maxRingFileSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.file.Write(line)
+	if err != nil {
+		return fmt.Errorf("events: failed to write event to ring buffer: %v", err)
+	}
+	r.size += int64(n)
+
+	// Journald is best effort: a pod/app event must not fail just because
+	// systemd-journald is unavailable (e.g. in a container without it).
+	if journal.Enabled() {
+		vars := map[string]string{
+			"SYSLOG_IDENTIFIER": journalIdentifier,
+			"EVENT_TYPE":        string(ev.Type),
+		}
+		if ev.PodUUID != "" {
+			vars["POD_UUID"] = ev.PodUUID
+		}
+		if ev.AppName != "" {
+			vars["APP_NAME"] = ev.AppName
+		}
+		if ev.ImageID != "" {
+			vars["IMAGE_ID"] = ev.ImageID
+		}
+		for k, v := range ev.Fields {
+			vars[k] = v
+		}
+		journal.Send(ev.Message, journal.PriInfo, vars)
+	}
+
+	return nil
+}
+
+// Close closes the underlying ring buffer file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// Filter narrows which events a Follower should deliver. It mirrors the
+// shape of v1alpha.EventFilter: empty slices/zero times mean "no constraint".
+type Filter struct {
+	Types     []Type
+	PodIds    []string
+	Names     []string
+	SinceTime time.Time
+	UntilTime time.Time
+}
+
+func (f Filter) matches(ev Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if ev.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.PodIds) > 0 {
+		found := false
+		for _, id := range f.PodIds {
+			if ev.PodUUID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Names) > 0 {
+		found := false
+		for _, name := range f.Names {
+			if ev.AppName == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.SinceTime.IsZero() && ev.Time.Before(f.SinceTime) {
+		return false
+	}
+	if !f.UntilTime.IsZero() && ev.Time.After(f.UntilTime) {
+		return false
+	}
+	return true
+}
+
+// Follow streams events matching filter to the returned channel until stop
+// is closed, replaying history back to since first (the zero Time means no
+// lower bound, matching a filter with no SinceTime bound). It prefers
+// tailing the systemd journal (sd_journal_wait) and falls back to polling
+// the ring buffer file every 200ms when journald is not reachable on this
+// host. That fallback uses no inotify watch, and it replays only the
+// current ring buffer file, not its rotated backups.
+func Follow(dataDir string, since time.Time, filter Filter, stop <-chan struct{}) (<-chan Event, <-chan error) {
+	out := make(chan Event)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+
+		if j, err := sdjournal.NewJournal(); err == nil {
+			defer j.Close()
+			followJournal(j, since, filter, out, stop)
+			return
+		}
+
+		// journald is unavailable (e.g. no /run/log/journal): fall back to
+		// polling the ring buffer file.
+		if err := followRingFile(filepath.Join(dataDir, ringFileName), since, filter, out, stop); err != nil {
+			errc <- err
+		}
+	}()
+
+	return out, errc
+}
+
+func followJournal(j *sdjournal.Journal, since time.Time, filter Filter, out chan<- Event, stop <-chan struct{}) {
+	j.AddMatch("SYSLOG_IDENTIFIER=" + journalIdentifier)
+	for _, t := range filter.Types {
+		j.AddMatch("EVENT_TYPE=" + string(t))
+	}
+	if since.IsZero() {
+		j.SeekHead()
+	} else {
+		j.SeekRealtimeUsec(uint64(since.UnixNano()) / uint64(time.Microsecond))
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		n, err := j.Next()
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			j.Wait(time.Second)
+			continue
+		}
+
+		entry, err := j.GetEntry()
+		if err != nil {
+			continue
+		}
+		ev := Event{
+			Type:    Type(entry.Fields["EVENT_TYPE"]),
+			Time:    time.Unix(0, int64(entry.RealtimeTimestamp)*int64(time.Microsecond)),
+			PodUUID: entry.Fields["POD_UUID"],
+			AppName: entry.Fields["APP_NAME"],
+			ImageID: entry.Fields["IMAGE_ID"],
+			Message: entry.Fields["MESSAGE"],
+		}
+		if filter.matches(ev) {
+			out <- ev
+		}
+	}
+}
+
+func followRingFile(path string, since time.Time, filter Filter, out chan<- Event, stop <-chan struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("events: failed to open ring buffer file for tailing: %v", err)
+	}
+	defer f.Close()
+
+	// Replay what's already there, then poll for appends every 200ms. No
+	// inotify/fsnotify watch is used; polling keeps this fallback
+	// dependency-free.
+	r := bufio.NewReader(f)
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		for {
+			line, err := r.ReadString('\n')
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			var ev Event
+			if err := json.Unmarshal([]byte(line), &ev); err != nil {
+				continue
+			}
+			if !since.IsZero() && ev.Time.Before(since) {
+				continue
+			}
+			if filter.matches(ev) {
+				out <- ev
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMatches(t *testing.T) {
+	base := Event{
+		Type:    TypePodStarted,
+		PodUUID: "abc-123",
+		AppName: "myapp",
+		Time:    time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		ev     Event
+		want   bool
+	}{
+		{"empty filter matches anything", Filter{}, base, true},
+		{"type matches", Filter{Types: []Type{TypePodStarted}}, base, true},
+		{"type mismatches", Filter{Types: []Type{TypePodExited}}, base, false},
+		{"any configured type matches", Filter{Types: []Type{TypePodExited, TypePodStarted}}, base, true},
+		{"pod id matches", Filter{PodIds: []string{"abc-123"}}, base, true},
+		{"pod id mismatches", Filter{PodIds: []string{"other"}}, base, false},
+		{"name matches", Filter{Names: []string{"myapp"}}, base, true},
+		{"name mismatches", Filter{Names: []string{"otherapp"}}, base, false},
+		{
+			"since excludes events before it",
+			Filter{SinceTime: time.Date(2016, 1, 3, 0, 0, 0, 0, time.UTC)},
+			base,
+			false,
+		},
+		{
+			"until excludes events after it",
+			Filter{UntilTime: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)},
+			base,
+			false,
+		},
+		{
+			"all constraints must hold",
+			Filter{Types: []Type{TypePodStarted}, PodIds: []string{"abc-123"}, Names: []string{"otherapp"}},
+			base,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(tt.ev); got != tt.want {
+				t.Errorf("Filter%+v.matches(%+v) = %v, want %v", tt.filter, tt.ev, got, tt.want)
+			}
+		})
+	}
+}
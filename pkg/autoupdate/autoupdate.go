@@ -0,0 +1,251 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package autoupdate implements a background reconciler that keeps
+// participating pods on the latest signed image available from their
+// discovery endpoint, replacing them in place (prepare-new, stop-old,
+// run-new) when a newer digest shows up.
+package autoupdate
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnnotationAutoUpdate is the pod-manifest annotation that opts a pod into
+// auto-update reconciliation. Its value names the update strategy; today
+// only "registry" (re-resolve the image's discovery/registry URL) is
+// supported.
+const AnnotationAutoUpdate = "io.rkt.auto-update"
+
+// StrategyRegistry is the only currently supported AnnotationAutoUpdate
+// value: re-resolve the app's image name through discovery and compare the
+// resulting blob key against what the pod is running.
+const StrategyRegistry = "registry"
+
+// Status is the outcome of reconciling a single pod.
+type Status string
+
+const (
+	StatusUpToDate Status = "up-to-date"
+	StatusUpdated  Status = "updated"
+	StatusFailed   Status = "failed"
+)
+
+// PodUpdate reports the outcome of reconciling one pod, for ListAutoUpdates
+// / TriggerAutoUpdate to surface to a CLI or scheduler. PodId is the pod
+// that's now running the app: the original pod's ID until a replacement
+// succeeds, at which point it becomes the new pod's ID; OldPodId always
+// stays the original pod's ID, so callers can still refer to it (e.g. to
+// record that it exited) after PodId has moved on.
+type PodUpdate struct {
+	PodId      string
+	OldPodId   string
+	AppName    string
+	Status     Status
+	OldImageId string
+	NewImageId string
+	Error      string
+}
+
+// ImageResolver looks up the blob key an app image name currently resolves
+// to (e.g. via appc discovery) and fetches/imports it into the local store
+// if it's not already present, returning its blob key. It is supplied by
+// the caller so the reconciler itself stays independent of the fetcher and
+// image store implementations.
+type ImageResolver interface {
+	ResolveCurrent(appName string) (imageID string, err error)
+	FetchAndImport(appName string) (imageID string, err error)
+}
+
+// PodReplacer performs the prepare-new/stop-old/run-new dance for a pod
+// that needs to move to a new image, preserving the pod's UUID annotations
+// and network config. It is supplied by the caller (the rkt CLI package)
+// since it needs access to pod preparation/run machinery that autoupdate
+// itself doesn't own.
+type PodReplacer interface {
+	// Replace prepares and starts a replacement for podID running newImageID
+	// in place of oldImageID for the given app, waiting up to timeout for it
+	// to reach the Running state. It returns the new pod's ID.
+	Replace(podID, appName, oldImageID, newImageID string, timeout time.Duration) (newPodID string, err error)
+}
+
+// Pod is the minimal view of a running pod the reconciler needs.
+type Pod struct {
+	Id          string
+	Annotations map[string]string
+	Apps        []App
+}
+
+// App is the minimal view of an app within a pod the reconciler needs.
+type App struct {
+	Name    string
+	ImageId string
+}
+
+// PodLister returns the currently running pods eligible for reconciliation.
+type PodLister interface {
+	ListRunningPods() ([]Pod, error)
+}
+
+// Reconciler periodically compares each participating pod's running image
+// against what its discovery endpoint currently resolves to, and triggers a
+// PodReplacer.Replace when they differ.
+type Reconciler struct {
+	Pods     PodLister
+	Images   ImageResolver
+	Replace  PodReplacer
+	Interval time.Duration
+	Timeout  time.Duration
+
+	// Rollback is the rollback behavior used by the background Run loop's
+	// ticks. It's read-only after construction: ReconcileOnce (and thus
+	// Run) never mutates it, so it's safe to read concurrently with an
+	// on-demand ReconcileOne call running in another goroutine. On-demand
+	// callers that want different rollback behavior for a single call
+	// should pass it to ReconcileOne directly instead of overwriting this
+	// field.
+	Rollback bool
+
+	// OnUpdate, if set, is called with the results of every ReconcileOnce
+	// run by Run so a caller can record them (e.g. for ListAutoUpdates)
+	// the same way it would a synchronous, RPC-triggered reconcile.
+	OnUpdate func([]PodUpdate)
+}
+
+// NewReconciler returns a Reconciler with the given collaborators, an
+// Interval of 5 minutes and a Timeout of 2 minutes.
+func NewReconciler(pods PodLister, images ImageResolver, replace PodReplacer) *Reconciler {
+	return &Reconciler{
+		Pods:     pods,
+		Images:   images,
+		Replace:  replace,
+		Interval: 5 * time.Minute,
+		Timeout:  2 * time.Minute,
+	}
+}
+
+// Run loops ReconcileOnce every Interval until stop is closed.
+func (r *Reconciler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		updates := r.ReconcileOnce()
+		if r.OnUpdate != nil {
+			r.OnUpdate(updates)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileOnce walks every running, auto-update-annotated pod once and
+// returns a PodUpdate per app that was considered, rolling back per r.Rollback.
+func (r *Reconciler) ReconcileOnce() []PodUpdate {
+	pods, err := r.Pods.ListRunningPods()
+	if err != nil {
+		return []PodUpdate{{Status: StatusFailed, Error: fmt.Sprintf("failed to list pods: %v", err)}}
+	}
+
+	var updates []PodUpdate
+	for _, pod := range pods {
+		if !participatesInAutoUpdate(pod) {
+			continue
+		}
+		for _, app := range pod.Apps {
+			updates = append(updates, r.reconcileApp(pod, app, r.Rollback))
+		}
+	}
+	return updates
+}
+
+// ReconcileOne reconciles a single running, participating pod on demand,
+// using rollback for this call only. Unlike ReconcileOnce/Run it never
+// touches r.Rollback, so it's safe to call concurrently with a background
+// Run loop without synchronization.
+func (r *Reconciler) ReconcileOne(podID string, rollback bool) ([]PodUpdate, error) {
+	pods, err := r.Pods.ListRunningPods()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	for _, pod := range pods {
+		if pod.Id != podID {
+			continue
+		}
+		if !participatesInAutoUpdate(pod) {
+			return nil, fmt.Errorf("pod %q is not participating in auto-update", podID)
+		}
+
+		var updates []PodUpdate
+		for _, app := range pod.Apps {
+			updates = append(updates, r.reconcileApp(pod, app, rollback))
+		}
+		return updates, nil
+	}
+	return nil, fmt.Errorf("pod %q is not running", podID)
+}
+
+// participatesInAutoUpdate reports whether pod opted into reconciliation via
+// AnnotationAutoUpdate.
+func participatesInAutoUpdate(pod Pod) bool {
+	strategy, ok := pod.Annotations[AnnotationAutoUpdate]
+	return ok && strategy == StrategyRegistry
+}
+
+func (r *Reconciler) reconcileApp(pod Pod, app App, rollback bool) PodUpdate {
+	update := PodUpdate{PodId: pod.Id, OldPodId: pod.Id, AppName: app.Name, OldImageId: app.ImageId}
+
+	latest, err := r.Images.ResolveCurrent(app.Name)
+	if err != nil {
+		update.Status = StatusFailed
+		update.Error = fmt.Sprintf("failed to resolve current image for %q: %v", app.Name, err)
+		return update
+	}
+
+	if latest == app.ImageId {
+		update.Status = StatusUpToDate
+		return update
+	}
+
+	newImageID, err := r.Images.FetchAndImport(app.Name)
+	if err != nil {
+		update.Status = StatusFailed
+		update.Error = fmt.Sprintf("failed to fetch new image for %q: %v", app.Name, err)
+		return update
+	}
+	update.NewImageId = newImageID
+
+	newPodID, err := r.Replace.Replace(pod.Id, app.Name, app.ImageId, newImageID, r.Timeout)
+	if err != nil {
+		update.Status = StatusFailed
+		update.Error = fmt.Sprintf("failed to replace pod %q: %v", pod.Id, err)
+
+		if rollback {
+			if _, rbErr := r.Replace.Replace(pod.Id, app.Name, newImageID, app.ImageId, r.Timeout); rbErr != nil {
+				update.Error += fmt.Sprintf("; rollback also failed: %v", rbErr)
+			}
+		}
+		return update
+	}
+
+	update.Status = StatusUpdated
+	update.PodId = newPodID
+	return update
+}
@@ -0,0 +1,238 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoupdate
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type fakePodLister struct {
+	pods []Pod
+	err  error
+}
+
+func (f fakePodLister) ListRunningPods() ([]Pod, error) {
+	return f.pods, f.err
+}
+
+type fakeImageResolver struct {
+	current      string
+	resolveErr   error
+	fetchImageID string
+	fetchErr     error
+}
+
+func (f fakeImageResolver) ResolveCurrent(appName string) (string, error) {
+	return f.current, f.resolveErr
+}
+
+func (f fakeImageResolver) FetchAndImport(appName string) (string, error) {
+	return f.fetchImageID, f.fetchErr
+}
+
+type fakePodReplacer struct {
+	replaceErr  error
+	rollbackErr error
+	calls       []string
+}
+
+func (f *fakePodReplacer) Replace(podID, appName, oldImageID, newImageID string, timeout time.Duration) (string, error) {
+	call := fmt.Sprintf("%s->%s", oldImageID, newImageID)
+	for _, prev := range f.calls {
+		if prev == fmt.Sprintf("%s->%s", newImageID, oldImageID) {
+			// A rollback call reverses an earlier forward call, passing
+			// the original image back as newImageID.
+			f.calls = append(f.calls, call)
+			return podID, f.rollbackErr
+		}
+	}
+	f.calls = append(f.calls, call)
+	if f.replaceErr != nil {
+		return "", f.replaceErr
+	}
+	return "new-" + podID, nil
+}
+
+func annotatedPod(id, appName, imageID string) Pod {
+	return Pod{
+		Id:          id,
+		Annotations: map[string]string{AnnotationAutoUpdate: StrategyRegistry},
+		Apps:        []App{{Name: appName, ImageId: imageID}},
+	}
+}
+
+func TestReconcileOnceSkipsUnannotatedPods(t *testing.T) {
+	r := &Reconciler{
+		Pods: fakePodLister{pods: []Pod{
+			{Id: "pod1", Apps: []App{{Name: "app", ImageId: "old"}}},
+		}},
+		Images:  fakeImageResolver{current: "new"},
+		Replace: &fakePodReplacer{},
+	}
+
+	if updates := r.ReconcileOnce(); len(updates) != 0 {
+		t.Errorf("ReconcileOnce on an unannotated pod returned %d updates, want 0", len(updates))
+	}
+}
+
+func TestReconcileOnceUpToDate(t *testing.T) {
+	r := &Reconciler{
+		Pods:    fakePodLister{pods: []Pod{annotatedPod("pod1", "app", "same")}},
+		Images:  fakeImageResolver{current: "same"},
+		Replace: &fakePodReplacer{},
+	}
+
+	updates := r.ReconcileOnce()
+	if len(updates) != 1 || updates[0].Status != StatusUpToDate {
+		t.Fatalf("ReconcileOnce = %+v, want a single StatusUpToDate update", updates)
+	}
+}
+
+func TestReconcileOnceUpdates(t *testing.T) {
+	replacer := &fakePodReplacer{}
+	r := &Reconciler{
+		Pods:    fakePodLister{pods: []Pod{annotatedPod("pod1", "app", "old")}},
+		Images:  fakeImageResolver{current: "new", fetchImageID: "new"},
+		Replace: replacer,
+	}
+
+	updates := r.ReconcileOnce()
+	if len(updates) != 1 || updates[0].Status != StatusUpdated {
+		t.Fatalf("ReconcileOnce = %+v, want a single StatusUpdated update", updates)
+	}
+	if updates[0].PodId != "new-pod1" {
+		t.Errorf("ReconcileOnce update PodId = %q, want the replacement pod id", updates[0].PodId)
+	}
+	if updates[0].OldPodId != "pod1" {
+		t.Errorf("ReconcileOnce update OldPodId = %q, want the original pod id to survive the replace", updates[0].OldPodId)
+	}
+}
+
+func TestReconcileOnceRollsBackOnFailedReplace(t *testing.T) {
+	replacer := &fakePodReplacer{replaceErr: fmt.Errorf("boom")}
+	r := &Reconciler{
+		Pods:     fakePodLister{pods: []Pod{annotatedPod("pod1", "app", "old")}},
+		Images:   fakeImageResolver{current: "new", fetchImageID: "new"},
+		Replace:  replacer,
+		Rollback: true,
+	}
+
+	updates := r.ReconcileOnce()
+	if len(updates) != 1 || updates[0].Status != StatusFailed {
+		t.Fatalf("ReconcileOnce = %+v, want a single StatusFailed update", updates)
+	}
+	if len(replacer.calls) != 2 {
+		t.Fatalf("Replace was called %d times, want 2 (forward attempt + rollback)", len(replacer.calls))
+	}
+	if replacer.calls[1] != "new->old" {
+		t.Errorf("rollback call = %q, want it to replace back to the original image", replacer.calls[1])
+	}
+}
+
+func TestReconcileOnceNoRollbackWhenDisabled(t *testing.T) {
+	replacer := &fakePodReplacer{replaceErr: fmt.Errorf("boom")}
+	r := &Reconciler{
+		Pods:    fakePodLister{pods: []Pod{annotatedPod("pod1", "app", "old")}},
+		Images:  fakeImageResolver{current: "new", fetchImageID: "new"},
+		Replace: replacer,
+	}
+
+	r.ReconcileOnce()
+	if len(replacer.calls) != 1 {
+		t.Errorf("Replace was called %d times with Rollback disabled, want 1 (no rollback attempt)", len(replacer.calls))
+	}
+}
+
+func TestReconcileOneOnlyReconcilesTheRequestedPod(t *testing.T) {
+	replacer := &fakePodReplacer{}
+	r := &Reconciler{
+		Pods: fakePodLister{pods: []Pod{
+			annotatedPod("pod1", "app", "old"),
+			annotatedPod("pod2", "app", "old"),
+		}},
+		Images:  fakeImageResolver{current: "new", fetchImageID: "new"},
+		Replace: replacer,
+	}
+
+	updates, err := r.ReconcileOne("pod1", false)
+	if err != nil {
+		t.Fatalf("ReconcileOne: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Status != StatusUpdated {
+		t.Fatalf("ReconcileOne(pod1) = %+v, want a single StatusUpdated update", updates)
+	}
+	for _, call := range replacer.calls {
+		if call != "old->new" {
+			t.Errorf("unexpected Replace call %q; ReconcileOne(pod1) must not touch pod2", call)
+		}
+	}
+	if len(replacer.calls) != 1 {
+		t.Errorf("Replace was called %d times, want exactly 1 (only the requested pod)", len(replacer.calls))
+	}
+}
+
+func TestReconcileOneUnknownPod(t *testing.T) {
+	r := &Reconciler{
+		Pods:    fakePodLister{pods: []Pod{annotatedPod("pod1", "app", "old")}},
+		Images:  fakeImageResolver{current: "old"},
+		Replace: &fakePodReplacer{},
+	}
+
+	if _, err := r.ReconcileOne("missing", false); err == nil {
+		t.Errorf("ReconcileOne on a pod that isn't running should return an error")
+	}
+}
+
+func TestReconcileOneNotParticipating(t *testing.T) {
+	r := &Reconciler{
+		Pods: fakePodLister{pods: []Pod{
+			{Id: "pod1", Apps: []App{{Name: "app", ImageId: "old"}}},
+		}},
+		Images:  fakeImageResolver{current: "old"},
+		Replace: &fakePodReplacer{},
+	}
+
+	if _, err := r.ReconcileOne("pod1", false); err == nil {
+		t.Errorf("ReconcileOne on an unannotated pod should return an error")
+	}
+}
+
+func TestReconcileOneRollbackIsPerCall(t *testing.T) {
+	// The background Reconciler.Rollback default must stay false while an
+	// on-demand ReconcileOne call uses its own rollback argument, with
+	// neither call mutating shared state.
+	replacer := &fakePodReplacer{replaceErr: fmt.Errorf("boom")}
+	r := &Reconciler{
+		Pods:    fakePodLister{pods: []Pod{annotatedPod("pod1", "app", "old")}},
+		Images:  fakeImageResolver{current: "new", fetchImageID: "new"},
+		Replace: replacer,
+	}
+
+	updates, err := r.ReconcileOne("pod1", true)
+	if err != nil {
+		t.Fatalf("ReconcileOne: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Status != StatusFailed {
+		t.Fatalf("ReconcileOne = %+v, want a single StatusFailed update", updates)
+	}
+	if len(replacer.calls) != 2 {
+		t.Fatalf("Replace was called %d times, want 2 (forward attempt + rollback)", len(replacer.calls))
+	}
+	if r.Rollback {
+		t.Errorf("ReconcileOne(..., true) must not mutate the Reconciler's own Rollback default")
+	}
+}
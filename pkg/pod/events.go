@@ -0,0 +1,61 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pod collects the lifecycle-event helpers callers use to record pod
+// transitions through an *events.Recorder. It's kept separate from the (much
+// larger) pod state machine itself so that callers only need a
+// *events.Recorder, not the whole pod package's internals. Today the only
+// caller is the auto-update replace path in rkt/api_autoupdate_service.go,
+// which uses EmitStarted/EmitExited to record a replacement pod's stop/start;
+// other transitions (create, prepare, garbage collection) aren't recorded
+// until something calls into this package for them.
+package pod
+
+import "github.com/coreos/rkt/events"
+
+// EmitStarted records that one of a pod's apps began running or, when
+// appName is empty, that the pod itself did. A single event is recorded.
+func EmitStarted(rec *events.Recorder, uuid, appName string) {
+	if appName != "" {
+		emit(rec, events.TypeAppStarted, uuid, appName, "")
+		return
+	}
+	emit(rec, events.TypePodStarted, uuid, "", "")
+}
+
+// EmitExited records that one of a pod's apps exited or, when appName is
+// empty, that the pod itself did. A single event is recorded.
+func EmitExited(rec *events.Recorder, uuid, appName string) {
+	if appName != "" {
+		emit(rec, events.TypeAppExited, uuid, appName, "")
+		return
+	}
+	emit(rec, events.TypePodExited, uuid, "", "")
+}
+
+func emit(rec *events.Recorder, t events.Type, uuid, appName, imageID string) {
+	if rec == nil {
+		return
+	}
+	if err := rec.Emit(events.Event{
+		Type:    t,
+		PodUUID: uuid,
+		AppName: appName,
+		ImageID: imageID,
+	}); err != nil {
+		// Event recording must never take down the pod state machine; the
+		// caller already has its own logging for the transition itself.
+		return
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/appc/spec/discovery"
+	"github.com/coreos/rkt/events"
+	"github.com/coreos/rkt/store/imagestore"
+	"golang.org/x/net/context"
+)
+
+// registryImageResolver is the autoupdate.ImageResolver used in production:
+// it re-runs appc discovery for the app name and compares against what's
+// actually served today.
+type registryImageResolver struct {
+	store    *imagestore.Store
+	recorder *events.Recorder
+}
+
+func (r registryImageResolver) discoverACIEndpoint(appName string) (string, error) {
+	app, err := discovery.NewAppFromString(appName)
+	if err != nil {
+		return "", fmt.Errorf("invalid app name %q: %v", appName, err)
+	}
+	endpoints, _, err := discovery.DiscoverEndpoints(*app, nil, true)
+	if err != nil {
+		return "", fmt.Errorf("discovery failed for %q: %v", appName, err)
+	}
+	if len(endpoints.ACIEndpoints) == 0 {
+		return "", fmt.Errorf("no ACI endpoints discovered for %q", appName)
+	}
+	return endpoints.ACIEndpoints[0].ACI, nil
+}
+
+// ResolveCurrent fetches the app's discovered ACI and hashes it, returning
+// the same sha512-<hex> key format the store uses, so callers can compare
+// it directly against a running app's Image.Id.
+func (r registryImageResolver) ResolveCurrent(appName string) (string, error) {
+	url, err := r.discoverACIEndpoint(appName)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %q: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	h := sha512.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to hash %q: %v", url, err)
+	}
+	return "sha512-" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FetchAndImport re-downloads the app's discovered ACI and imports it into
+// the local store, returning its blob key.
+func (r registryImageResolver) FetchAndImport(appName string) (string, error) {
+	url, err := r.discoverACIEndpoint(appName)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %q: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	key, err := r.store.WriteACI(resp.Body, imagestore.ACIFetchInfo{Latest: false})
+	if err != nil {
+		return "", fmt.Errorf("failed to import %q: %v", url, err)
+	}
+	imagestore.EmitImported(r.recorder, key, appName)
+	return key, nil
+}
+
+// execPodReplacer drives 'rkt run --replace' in a subprocess to perform the
+// prepare-new/stop-old/run-new dance. That machinery belongs to cmd/rkt's
+// own run command, so the background reconciler re-enters the binary it's
+// part of rather than duplicating pod preparation here.
+type execPodReplacer struct{}
+
+func (execPodReplacer) Replace(podID, appName, oldImageID, newImageID string, timeout time.Duration) (string, error) {
+	uuidFile, err := ioutil.TempFile("", "rkt-autoupdate-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create a uuid-file-save file: %v", err)
+	}
+	uuidFile.Close()
+	defer os.Remove(uuidFile.Name())
+
+	self, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the rkt binary: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, self, "run",
+		"--replace="+podID,
+		"--uuid-file-save="+uuidFile.Name(),
+		newImageID)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("rkt run --replace failed: %v: %s", err, out)
+	}
+
+	newUUID, err := ioutil.ReadFile(uuidFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the replacement pod's UUID: %v", err)
+	}
+	return strings.TrimSpace(string(newUUID)), nil
+}
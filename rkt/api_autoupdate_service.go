@@ -0,0 +1,133 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/coreos/rkt/api/v1alpha"
+	"github.com/coreos/rkt/pkg/autoupdate"
+	podevents "github.com/coreos/rkt/pkg/pod"
+	"golang.org/x/net/context"
+)
+
+// apiPodLister adapts walkPods/getBasicPod/fillAppInfo into the minimal
+// autoupdate.PodLister view, only surfacing pods that are actually running.
+type apiPodLister struct {
+	server *v1alphaAPIServer
+}
+
+func (l apiPodLister) ListRunningPods() ([]autoupdate.Pod, error) {
+	var pods []autoupdate.Pod
+	if err := walkPods(includeMostDirs, func(p *pod) {
+		v1pod := getBasicPod(p)
+		if v1pod.State != v1alpha.PodState_POD_STATE_RUNNING {
+			return
+		}
+		l.server.fillAppInfo(p, v1pod)
+
+		annotations := make(map[string]string, len(v1pod.Annotations))
+		for _, kv := range v1pod.Annotations {
+			annotations[kv.Key] = kv.Value
+		}
+
+		var apps []autoupdate.App
+		for _, app := range v1pod.Apps {
+			apps = append(apps, autoupdate.App{Name: app.Name, ImageId: app.Image.Id})
+		}
+
+		pods = append(pods, autoupdate.Pod{Id: v1pod.Id, Annotations: annotations, Apps: apps})
+	}); err != nil {
+		return nil, err
+	}
+	return pods, nil
+}
+
+// fillAppInfo is a thin wrapper so apiPodLister doesn't need direct access
+// to the store field of the embedded *v1alphaReadOnlyAPIServer.
+func (s *v1alphaAPIServer) fillAppInfo(p *pod, v1pod *v1alpha.Pod) {
+	fillAppInfo(s.store, p, v1pod)
+}
+
+// AutoUpdate runs one reconciliation pass over every pod annotated with
+// autoupdate.AnnotationAutoUpdate and returns the resulting per-pod status.
+func (s *v1alphaAPIServer) AutoUpdate(ctx context.Context, request *v1alpha.AutoUpdateRequest) (*v1alpha.AutoUpdateResponse, error) {
+	updates := s.autoUpdateRecon.ReconcileOnce()
+	s.recordAutoUpdates(updates)
+	return &v1alpha.AutoUpdateResponse{Updates: podUpdatesToV1Alpha(updates)}, nil
+}
+
+// recordAutoUpdates saves the latest status per pod for ListAutoUpdates and
+// emits a pod lifecycle event for every app that was actually replaced, so
+// 'rkt events'/journald shows the stop-old/start-new transition the
+// reconciler just drove.
+func (s *v1alphaAPIServer) recordAutoUpdates(updates []autoupdate.PodUpdate) {
+	s.autoUpdateMu.Lock()
+	defer s.autoUpdateMu.Unlock()
+
+	for _, u := range updates {
+		s.lastAutoUpdates[u.PodId] = u
+		if u.Status == autoupdate.StatusUpdated {
+			podevents.EmitExited(s.recorder, u.OldPodId, u.AppName)
+			podevents.EmitStarted(s.recorder, u.PodId, u.AppName)
+		}
+	}
+}
+
+// ListAutoUpdates returns the status recorded by the most recent
+// reconciliation pass (via AutoUpdate or the background reconciler loop),
+// without triggering a new one.
+func (s *v1alphaAPIServer) ListAutoUpdates(ctx context.Context, request *v1alpha.ListAutoUpdatesRequest) (*v1alpha.ListAutoUpdatesResponse, error) {
+	s.autoUpdateMu.Lock()
+	defer s.autoUpdateMu.Unlock()
+
+	updates := make([]autoupdate.PodUpdate, 0, len(s.lastAutoUpdates))
+	for _, u := range s.lastAutoUpdates {
+		updates = append(updates, u)
+	}
+	return &v1alpha.ListAutoUpdatesResponse{Updates: podUpdatesToV1Alpha(updates)}, nil
+}
+
+// TriggerAutoUpdate reconciles a single pod on demand, optionally rolling
+// back to the previous image if the replacement pod fails to reach Running
+// within the reconciler's timeout. The rollback choice applies only to this
+// call; it never touches the background reconciler's default.
+func (s *v1alphaAPIServer) TriggerAutoUpdate(ctx context.Context, request *v1alpha.TriggerAutoUpdateRequest) (*v1alpha.TriggerAutoUpdateResponse, error) {
+	updates, err := s.autoUpdateRecon.ReconcileOne(request.PodId, request.Rollback)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAutoUpdates(updates)
+
+	return &v1alpha.TriggerAutoUpdateResponse{Update: podUpdateToV1Alpha(updates[0])}, nil
+}
+
+func podUpdateToV1Alpha(u autoupdate.PodUpdate) *v1alpha.PodAutoUpdate {
+	return &v1alpha.PodAutoUpdate{
+		PodId:      u.PodId,
+		AppName:    u.AppName,
+		Status:     string(u.Status),
+		OldImageId: u.OldImageId,
+		NewImageId: u.NewImageId,
+		Error:      u.Error,
+	}
+}
+
+func podUpdatesToV1Alpha(updates []autoupdate.PodUpdate) []*v1alpha.PodAutoUpdate {
+	out := make([]*v1alpha.PodAutoUpdate, 0, len(updates))
+	for _, u := range updates {
+		out = append(out, podUpdateToV1Alpha(u))
+	}
+	return out
+}
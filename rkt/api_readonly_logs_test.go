@@ -0,0 +1,62 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExceedsUntilTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		tsUsec    uint64
+		untilTime int64
+		expected  bool
+	}{
+		{"unbounded when untilTime is zero", uint64(1000 * time.Second / time.Microsecond), 0, false},
+		{"entry before untilTime is kept", uint64(1000 * time.Second / time.Microsecond), 2000, false},
+		{"entry exactly at untilTime is kept", uint64(1000 * time.Second / time.Microsecond), 1000, false},
+		{"entry after untilTime is excluded", uint64(2000 * time.Second / time.Microsecond), 1000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exceedsUntilTime(tt.tsUsec, tt.untilTime); got != tt.expected {
+				t.Errorf("exceedsUntilTime(%d, %d) = %v, want %v", tt.tsUsec, tt.untilTime, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchesGrep(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		grep     string
+		expected bool
+	}{
+		{"empty grep matches everything", "hello world", "", true},
+		{"substring match", "hello world", "world", true},
+		{"no match", "hello world", "goodbye", false},
+		{"case sensitive", "Hello World", "hello", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesGrep(tt.message, tt.grep); got != tt.expected {
+				t.Errorf("matchesGrep(%q, %q) = %v, want %v", tt.message, tt.grep, got, tt.expected)
+			}
+		})
+	}
+}
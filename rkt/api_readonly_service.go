@@ -21,13 +21,17 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/appc/spec/schema"
 	"github.com/appc/spec/schema/types"
+	"github.com/coreos/go-systemd/sdjournal"
 	"github.com/coreos/rkt/api/v1alpha"
 	"github.com/coreos/rkt/common/cgroup"
+	"github.com/coreos/rkt/events"
 	"github.com/coreos/rkt/pkg/set"
 	"github.com/coreos/rkt/store/imagestore"
 	"github.com/coreos/rkt/version"
@@ -37,13 +41,18 @@ import (
 
 // v1alphaReadOnlyAPIServer implements v1alpha.PublicAPI interface.
 type v1alphaReadOnlyAPIServer struct {
-	store *imagestore.Store
+	store    *imagestore.Store
+	recorder *events.Recorder
 }
 
 var _ v1alpha.PublicAPIServer = &v1alphaReadOnlyAPIServer{}
 
 func newV1alphaReadOnlyAPIServer(s *imagestore.Store) (*v1alphaReadOnlyAPIServer, error) {
-	return &v1alphaReadOnlyAPIServer{store: s}, nil
+	rec, err := events.NewRecorder(getDataDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the event recorder: %v", err)
+	}
+	return &v1alphaReadOnlyAPIServer{store: s, recorder: rec}, nil
 }
 
 // GetInfo returns the information about the rkt, appc, api server version.
@@ -496,6 +505,490 @@ func (s *v1alphaReadOnlyAPIServer) InspectPod(ctx context.Context, request *v1al
 	return &v1alpha.InspectPodResponse{Pod: pod}, nil
 }
 
+// statsSampleInterval is the default spacing between the two cgroup/network
+// samples GetPodStats takes in order to compute CPU and network rates.
+const statsSampleInterval = 100 * time.Millisecond
+
+// cgroupIsUnified returns true if the host uses the unified (v2) cgroup
+// hierarchy, i.e. the "cgroup.controllers" file is present at the root.
+func cgroupIsUnified() bool {
+	_, err := os.Stat("/sys/fs/cgroup/cgroup.controllers")
+	return err == nil
+}
+
+// cgroupStatsSample is a single point-in-time reading of a cgroup's
+// resource-usage files.
+type cgroupStatsSample struct {
+	at          time.Time
+	cpuUsage    uint64 // nanoseconds
+	cpuPerCPU   []uint64
+	memUsage    uint64 // bytes
+	memStat     map[string]uint64
+	blkioBytes  uint64
+	pidsCurrent uint64
+}
+
+// readCgroupStats reads the cpuacct, memory, blkio and pids controllers for
+// the cgroup rooted at cgroupPath, supporting both the v1 per-controller
+// layout and the v2 unified hierarchy.
+func readCgroupStats(cgroupPath string) (*cgroupStatsSample, error) {
+	sample := &cgroupStatsSample{at: time.Now(), memStat: make(map[string]uint64)}
+
+	if cgroupIsUnified() {
+		base := filepath.Join("/sys/fs/cgroup", cgroupPath)
+		if v, err := readCgroupUint64(filepath.Join(base, "cpu.stat"), "usage_usec"); err == nil {
+			sample.cpuUsage = v * 1000
+		}
+		if v, err := readCgroupFirstUint64(filepath.Join(base, "memory.current")); err == nil {
+			sample.memUsage = v
+		}
+		if kvs, err := readCgroupKeyValues(filepath.Join(base, "memory.stat")); err == nil {
+			sample.memStat = kvs
+		}
+		// io.stat has one line per device: "MAJ:MIN rbytes=N wbytes=N ...".
+		if fields, err := readCgroupFields(filepath.Join(base, "io.stat")); err == nil {
+			for _, field := range fields {
+				kv := strings.SplitN(field, "=", 2)
+				if len(kv) != 2 || (kv[0] != "rbytes" && kv[0] != "wbytes") {
+					continue
+				}
+				if v, err := strconv.ParseUint(kv[1], 10, 64); err == nil {
+					sample.blkioBytes += v
+				}
+			}
+		}
+		if v, err := readCgroupFirstUint64(filepath.Join(base, "pids.current")); err == nil {
+			sample.pidsCurrent = v
+		}
+		return sample, nil
+	}
+
+	cpuacctBase := filepath.Join("/sys/fs/cgroup/cpuacct", cgroupPath)
+	if v, err := readCgroupFirstUint64(filepath.Join(cpuacctBase, "cpuacct.usage")); err == nil {
+		sample.cpuUsage = v
+	}
+	if fields, err := readCgroupFields(filepath.Join(cpuacctBase, "cpuacct.usage_percpu")); err == nil {
+		for _, f := range fields {
+			if v, err := strconv.ParseUint(f, 10, 64); err == nil {
+				sample.cpuPerCPU = append(sample.cpuPerCPU, v)
+			}
+		}
+	}
+
+	memoryBase := filepath.Join("/sys/fs/cgroup/memory", cgroupPath)
+	if v, err := readCgroupFirstUint64(filepath.Join(memoryBase, "memory.usage_in_bytes")); err == nil {
+		sample.memUsage = v
+	}
+	if kvs, err := readCgroupKeyValues(filepath.Join(memoryBase, "memory.stat")); err == nil {
+		sample.memStat = kvs
+	}
+
+	blkioBase := filepath.Join("/sys/fs/cgroup/blkio", cgroupPath)
+	if kvs, err := readCgroupKeyValues(filepath.Join(blkioBase, "blkio.throttle.io_service_bytes")); err == nil {
+		sample.blkioBytes = kvs["Total"]
+	}
+
+	pidsBase := filepath.Join("/sys/fs/cgroup/pids", cgroupPath)
+	if v, err := readCgroupFirstUint64(filepath.Join(pidsBase, "pids.current")); err == nil {
+		sample.pidsCurrent = v
+	}
+
+	return sample, nil
+}
+
+func readCgroupFields(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+func readCgroupFirstUint64(path string) (uint64, error) {
+	fields, err := readCgroupFields(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty cgroup file %q", path)
+	}
+	return strconv.ParseUint(fields[0], 10, 64)
+}
+
+// readCgroupKeyValues parses "key value" formatted cgroup files, e.g.
+// memory.stat or blkio.throttle.io_service_bytes (where non-leaf lines like
+// "Total 123" are also key-value pairs).
+func readCgroupKeyValues(path string) (map[string]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kvs := make(map[string]uint64)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[len(fields)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		kvs[fields[0]] = v
+	}
+	return kvs, nil
+}
+
+// readCgroupUint64 looks up key in a "key value\n..." formatted file such as
+// cpu.stat under the unified hierarchy.
+func readCgroupUint64(path, key string) (uint64, error) {
+	kvs, err := readCgroupKeyValues(path)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := kvs[key]
+	if !ok {
+		return 0, fmt.Errorf("key %q not found in %q", key, path)
+	}
+	return v, nil
+}
+
+// netDevStats holds the rx/tx byte counters for one network interface, read
+// from /proc/<pid>/net/dev inside the pod's network namespace.
+type netDevStats struct {
+	rxBytes uint64
+	txBytes uint64
+}
+
+func readNetDevStats(pid int) (map[string]netDevStats, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/net/dev", pid))
+	if err != nil {
+		return nil, err
+	}
+	return parseNetDevStats(string(data)), nil
+}
+
+// parseNetDevStats parses the body of a /proc/<pid>/net/dev read. It's split
+// out from readNetDevStats so the parsing itself can be unit tested without
+// a real /proc entry.
+func parseNetDevStats(data string) map[string]netDevStats {
+	stats := make(map[string]netDevStats)
+	lines := strings.Split(data, "\n")
+	if len(lines) < 2 {
+		// A pod whose network namespace is torn down between
+		// getContainerPID1() and this read (e.g. the app just exited)
+		// can yield a near-empty /proc/<pid>/net/dev; no interfaces to
+		// report rather than an error.
+		return stats
+	}
+	for _, line := range lines[2:] { // Skip the two header lines.
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		if name == "" || name == "lo" {
+			continue
+		}
+		fields := strings.Fields(parts[1])
+		if len(fields) < 9 {
+			continue
+		}
+		rx, _ := strconv.ParseUint(fields[0], 10, 64)
+		tx, _ := strconv.ParseUint(fields[8], 10, 64)
+		stats[name] = netDevStats{rxBytes: rx, txBytes: tx}
+	}
+	return stats
+}
+
+// buildPodStats samples the pod's and all its apps' cgroup and network
+// counters together, twice, 'interval' apart as a single pair of passes, and
+// derives CPU percentage and per-second rates from the deltas. Sampling the
+// pod and every app in the same pair of passes keeps the whole call bounded
+// to roughly 'interval', regardless of how many apps the pod has.
+func buildPodStats(p *pod, pod *v1alpha.Pod, interval time.Duration) (*v1alpha.PodStats, error) {
+	if pod.Cgroup == "" {
+		return nil, fmt.Errorf("pod %q has no cgroup, is it running?", pod.Id)
+	}
+
+	pid, err := p.getContainerPID1()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container PID1 for pod %q: %v", pod.Id, err)
+	}
+
+	appCgroups := make(map[string]string, len(pod.Apps))
+	for _, app := range pod.Apps {
+		cgroupPath, err := p.getAppCgroupPath(app.Name)
+		if err != nil {
+			stderr.PrintE(fmt.Sprintf("failed to get cgroup for app %q", app.Name), err)
+			continue
+		}
+		appCgroups[app.Name] = cgroupPath
+	}
+
+	firstPod, firstApps, firstNet, err := samplePodAndApps(pod.Cgroup, appCgroups, pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample cgroup stats for pod %q: %v", pod.Id, err)
+	}
+
+	time.Sleep(interval)
+
+	secondPod, secondApps, secondNet, err := samplePodAndApps(pod.Cgroup, appCgroups, pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample cgroup stats for pod %q: %v", pod.Id, err)
+	}
+
+	elapsed := secondPod.at.Sub(firstPod.at).Seconds()
+
+	stats := &v1alpha.PodStats{
+		Id:          pod.Id,
+		CpuUsage:    secondPod.cpuUsage,
+		CpuPercent:  cpuPercent(firstPod.cpuUsage, secondPod.cpuUsage, elapsed),
+		MemoryUsage: secondPod.memUsage,
+		MemoryRss:   secondPod.memStat["rss"],
+		MemoryCache: secondPod.memStat["cache"],
+		MemorySwap:  secondPod.memStat["swap"],
+		BlkioBytes:  secondPod.blkioBytes,
+		PidsCurrent: secondPod.pidsCurrent,
+		Networks:    networkStatsDelta(firstNet, secondNet, elapsed),
+	}
+
+	for _, app := range pod.Apps {
+		first, ok := firstApps[app.Name]
+		if !ok {
+			continue
+		}
+		second, ok := secondApps[app.Name]
+		if !ok {
+			continue
+		}
+		stats.Apps = append(stats.Apps, appStatsFromSamples(app.Name, first, second))
+	}
+
+	return stats, nil
+}
+
+// samplePodAndApps takes one point-in-time reading of the pod's cgroup,
+// network counters and every named app cgroup. An app whose cgroup can't be
+// read is logged and omitted, the same way buildPodStats always handled a
+// per-app failure.
+func samplePodAndApps(podCgroup string, appCgroups map[string]string, pid int) (*cgroupStatsSample, map[string]*cgroupStatsSample, map[string]netDevStats, error) {
+	pod, err := readCgroupStats(podCgroup)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	net, _ := readNetDevStats(pid)
+
+	apps := make(map[string]*cgroupStatsSample, len(appCgroups))
+	for name, cgroupPath := range appCgroups {
+		sample, err := readCgroupStats(cgroupPath)
+		if err != nil {
+			stderr.PrintE(fmt.Sprintf("failed to get stats for app %q", name), err)
+			continue
+		}
+		apps[name] = sample
+	}
+
+	return pod, apps, net, nil
+}
+
+// appStatsFromSamples derives an app's CPU percentage and point-in-time
+// usage from two cgroup samples 'interval' apart.
+func appStatsFromSamples(name string, first, second *cgroupStatsSample) *v1alpha.AppStats {
+	elapsed := second.at.Sub(first.at).Seconds()
+	return &v1alpha.AppStats{
+		Name:        name,
+		CpuUsage:    second.cpuUsage,
+		CpuPercent:  cpuPercent(first.cpuUsage, second.cpuUsage, elapsed),
+		MemoryUsage: second.memUsage,
+		MemoryRss:   second.memStat["rss"],
+		MemoryCache: second.memStat["cache"],
+		MemorySwap:  second.memStat["swap"],
+		PidsCurrent: second.pidsCurrent,
+	}
+}
+
+// buildAppStats samples a single app's subcgroup twice, 'interval' apart, for
+// GetAppStats' standalone single-app call.
+func buildAppStats(p *pod, app *v1alpha.App, interval time.Duration) (*v1alpha.AppStats, error) {
+	cgroupPath, err := p.getAppCgroupPath(app.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cgroup for app %q: %v", app.Name, err)
+	}
+
+	first, err := readCgroupStats(cgroupPath)
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(interval)
+	second, err := readCgroupStats(cgroupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return appStatsFromSamples(app.Name, first, second), nil
+}
+
+// cpuPercent derives a CPU utilization percentage from two cpuacct.usage
+// (nanosecond) samples 'elapsed' seconds apart, normalized by the number of
+// available CPUs the same way 'docker stats'/'kubectl top' do.
+func cpuPercent(firstNanos, secondNanos uint64, elapsed float64) float64 {
+	if elapsed <= 0 || secondNanos < firstNanos {
+		return 0
+	}
+	deltaSeconds := float64(secondNanos-firstNanos) / float64(time.Second)
+	return (deltaSeconds / elapsed) / float64(runtime.NumCPU()) * 100
+}
+
+// networkStatsDelta turns two /proc/net/dev samples into a per-second rx/tx
+// rate per interface.
+func networkStatsDelta(first, second map[string]netDevStats, elapsed float64) []*v1alpha.NetworkStats {
+	var out []*v1alpha.NetworkStats
+	if elapsed <= 0 {
+		return out
+	}
+	for name, s := range second {
+		f := first[name]
+		rxRate := float64(0)
+		txRate := float64(0)
+		if s.rxBytes >= f.rxBytes {
+			rxRate = float64(s.rxBytes-f.rxBytes) / elapsed
+		}
+		if s.txBytes >= f.txBytes {
+			txRate = float64(s.txBytes-f.txBytes) / elapsed
+		}
+		out = append(out, &v1alpha.NetworkStats{
+			Interface:        name,
+			RxBytesPerSecond: uint64(rxRate),
+			TxBytesPerSecond: uint64(txRate),
+		})
+	}
+	return out
+}
+
+// GetPodStats samples cpuacct/memory/blkio/pids and network counters for a
+// running pod twice, 'interval' apart, to compute CPU% and per-second
+// network rates. See buildPodStats for the sampling details.
+func (s *v1alphaReadOnlyAPIServer) GetPodStats(ctx context.Context, request *v1alpha.GetPodStatsRequest) (*v1alpha.GetPodStatsResponse, error) {
+	uuid, err := types.NewUUID(request.Id)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("invalid pod id %q", request.Id), err)
+		return nil, err
+	}
+
+	p, err := getPod(uuid)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("failed to get pod %q", request.Id), err)
+		return nil, err
+	}
+	defer p.Close()
+
+	pod := getBasicPod(p)
+	fillAppInfo(s.store, p, pod)
+
+	interval := statsSampleInterval
+	if request.IntervalSeconds > 0 {
+		interval = time.Duration(request.IntervalSeconds) * time.Second
+	}
+
+	stats, err := buildPodStats(p, pod, interval)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("failed to get stats for pod %q", request.Id), err)
+		return nil, err
+	}
+
+	return &v1alpha.GetPodStatsResponse{Stats: stats}, nil
+}
+
+// GetAppStats returns resource usage for a single app within a pod.
+func (s *v1alphaReadOnlyAPIServer) GetAppStats(ctx context.Context, request *v1alpha.GetAppStatsRequest) (*v1alpha.GetAppStatsResponse, error) {
+	uuid, err := types.NewUUID(request.PodId)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("invalid pod id %q", request.PodId), err)
+		return nil, err
+	}
+
+	p, err := getPod(uuid)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("failed to get pod %q", request.PodId), err)
+		return nil, err
+	}
+	defer p.Close()
+
+	pod := getBasicPod(p)
+	fillAppInfo(s.store, p, pod)
+
+	var target *v1alpha.App
+	for _, app := range pod.Apps {
+		if app.Name == request.AppName {
+			target = app
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("app %q not found in pod %q", request.AppName, request.PodId)
+	}
+
+	interval := statsSampleInterval
+	if request.IntervalSeconds > 0 {
+		interval = time.Duration(request.IntervalSeconds) * time.Second
+	}
+
+	stats, err := buildAppStats(p, target, interval)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("failed to get stats for app %q", request.AppName), err)
+		return nil, err
+	}
+
+	return &v1alpha.GetAppStatsResponse{Stats: stats}, nil
+}
+
+// StreamPodStats pushes a PodStats sample every IntervalSeconds until the
+// client cancels the stream's context.
+func (s *v1alphaReadOnlyAPIServer) StreamPodStats(request *v1alpha.StreamPodStatsRequest, stream v1alpha.PublicAPI_StreamPodStatsServer) error {
+	interval := statsSampleInterval
+	if request.IntervalSeconds > 0 {
+		interval = time.Duration(request.IntervalSeconds) * time.Second
+	}
+
+	uuid, err := types.NewUUID(request.Id)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("invalid pod id %q", request.Id), err)
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		default:
+		}
+
+		p, err := getPod(uuid)
+		if err != nil {
+			stderr.PrintE(fmt.Sprintf("failed to get pod %q", request.Id), err)
+			return err
+		}
+
+		pod := getBasicPod(p)
+		fillAppInfo(s.store, p, pod)
+
+		stats, err := buildPodStats(p, pod, interval)
+		p.Close()
+		if err != nil {
+			stderr.PrintE(fmt.Sprintf("failed to get stats for pod %q", request.Id), err)
+			return err
+		}
+
+		if err := stream.Send(&v1alpha.StreamPodStatsResponse{Stats: stats}); err != nil {
+			return err
+		}
+
+		// buildPodStats already blocked for a full interval taking its
+		// two-point sample; looping straight back into it gives a send
+		// roughly every interval, not every 2*interval.
+	}
+}
+
 // aciInfoToV1AlphaAPIImage takes an aciInfo object and construct the v1alpha.Image object.
 func aciInfoToV1AlphaAPIImage(store *imagestore.Store, aciInfo *imagestore.ACIInfo) (*v1alpha.Image, error) {
 	manifest, err := store.GetImageManifestJSON(aciInfo.BlobKey)
@@ -530,9 +1023,117 @@ func aciInfoToV1AlphaAPIImage(store *imagestore.Store, aciInfo *imagestore.ACIIn
 		Size:            aciInfo.Size + aciInfo.TreeStoreSize,
 		Annotations:     convertAnnotationsToKeyValue(im.Annotations),
 		Labels:          convertLabelsToKeyValue(im.Labels),
+		Platform:        imageManifestPlatform(im),
 	}, nil
 }
 
+// imageManifestPlatform extracts the (os, arch, variant) labels an ACI
+// carries, appc's own way of distinguishing instances of the same image
+// name built for different platforms.
+func imageManifestPlatform(im schema.ImageManifest) *v1alpha.Platform {
+	os, _ := im.Labels.Get("os")
+	arch, _ := im.Labels.Get("arch")
+	variant, _ := im.Labels.Get("variant")
+	if os == "" && arch == "" && variant == "" {
+		return nil
+	}
+	return &v1alpha.Platform{Os: os, Arch: arch, Variant: variant}
+}
+
+// satisfiesPlatform returns true if platform is unset (no constraint), or
+// every non-empty field in platform matches the corresponding field in p.
+func satisfiesPlatform(p *v1alpha.Platform, platform *v1alpha.Platform) bool {
+	if platform == nil {
+		return true
+	}
+	if p == nil {
+		return false
+	}
+	if platform.Os != "" && platform.Os != p.Os {
+		return false
+	}
+	if platform.Arch != "" && platform.Arch != p.Arch {
+		return false
+	}
+	if platform.Variant != "" && platform.Variant != p.Variant {
+		return false
+	}
+	return true
+}
+
+// hostPlatform returns the running host's platform, used to pick a default
+// instance out of a manifest list when the caller doesn't ask for a
+// specific one.
+func hostPlatform() *v1alpha.Platform {
+	return &v1alpha.Platform{Os: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// manifestListKey returns the key under which image should be grouped for
+// the purpose of collapsing manifest-list platform variants. Images carry
+// no Platform labels at all (the overwhelming majority of the store, since
+// most ACIs aren't part of a manifest list) are kept in their own singleton
+// group keyed by Id, so unrelated images - in particular different versions
+// of the same app, which legitimately share Name - are never merged
+// together. Only images that actually advertise (os, arch, variant) labels
+// for the same (Name, Version) are considered siblings of one logical
+// manifest-list image.
+func manifestListKey(image *v1alpha.Image) string {
+	if image.Platform == nil {
+		return "id:" + image.Id
+	}
+	return "name-version:" + image.Name + "@" + image.Version
+}
+
+// groupImagesByManifestList groups images into their manifest-list siblings;
+// see manifestListKey for how membership is decided.
+func groupImagesByManifestList(images []*v1alpha.Image) map[string][]*v1alpha.Image {
+	groups := make(map[string][]*v1alpha.Image)
+	for _, image := range images {
+		key := manifestListKey(image)
+		groups[key] = append(groups[key], image)
+	}
+	return groups
+}
+
+// selectPlatformInstance picks the instance matching platform out of
+// instances, falling back to the host's platform and then to the first
+// instance if neither matches. It returns nil if instances is empty.
+func selectPlatformInstance(instances []*v1alpha.Image, platform *v1alpha.Platform) *v1alpha.Image {
+	if len(instances) == 0 {
+		return nil
+	}
+	if platform != nil {
+		for _, image := range instances {
+			if satisfiesPlatform(image.Platform, platform) {
+				return image
+			}
+		}
+	}
+	host := hostPlatform()
+	for _, image := range instances {
+		if satisfiesPlatform(image.Platform, host) {
+			return image
+		}
+	}
+	return instances[0]
+}
+
+// manifestListSiblings builds the ManifestList summary attached to the
+// instance ListImages/InspectImage chooses to represent a multi-arch image.
+func manifestListSiblings(chosen *v1alpha.Image, instances []*v1alpha.Image) *v1alpha.ManifestList {
+	if len(instances) < 2 {
+		return nil
+	}
+	list := &v1alpha.ManifestList{Name: chosen.Name}
+	for _, image := range instances {
+		list.Instances = append(list.Instances, &v1alpha.ManifestListInstance{
+			Id:       image.Id,
+			Platform: image.Platform,
+		})
+	}
+	return list
+}
+
 func convertAnnotationsToKeyValue(as types.Annotations) []*v1alpha.KeyValue {
 	kvs := make([]*v1alpha.KeyValue, 0, len(as))
 	for _, a := range as {
@@ -626,6 +1227,11 @@ func satisfiesImageFilter(image v1alpha.Image, filter v1alpha.ImageFilter) bool
 		}
 	}
 
+	// Filter according to the platform (os/arch/variant).
+	if filter.Platform != nil && !satisfiesPlatform(image.Platform, filter.Platform) {
+		return false
+	}
+
 	return true
 }
 
@@ -644,6 +1250,18 @@ func satisfiesAnyImageFilters(image *v1alpha.Image, filters []*v1alpha.ImageFilt
 	return false
 }
 
+// requestsSpecificPlatform returns true if any of the filters asks for a
+// specific platform, in which case ListImages must not collapse manifest
+// lists down to a single default instance.
+func requestsSpecificPlatform(filters []*v1alpha.ImageFilter) bool {
+	for _, filter := range filters {
+		if filter.Platform != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *v1alphaReadOnlyAPIServer) ListImages(ctx context.Context, request *v1alpha.ListImagesRequest) (*v1alpha.ListImagesResponse, error) {
 	aciInfos, err := s.store.GetAllACIInfos(nil, false)
 	if err != nil {
@@ -651,7 +1269,7 @@ func (s *v1alphaReadOnlyAPIServer) ListImages(ctx context.Context, request *v1al
 		return nil, err
 	}
 
-	var images []*v1alpha.Image
+	var matched []*v1alpha.Image
 	for _, aciInfo := range aciInfos {
 		image, err := aciInfoToV1AlphaAPIImage(s.store, aciInfo)
 		if err != nil {
@@ -660,10 +1278,28 @@ func (s *v1alphaReadOnlyAPIServer) ListImages(ctx context.Context, request *v1al
 		if !satisfiesAnyImageFilters(image, request.Filters) {
 			continue
 		}
-		if !request.Detail {
-			image.Manifest = nil // Do not return image manifest in ListImages(detail=false).
+		matched = append(matched, image)
+	}
+
+	expand := request.Detail || requestsSpecificPlatform(request.Filters)
+
+	var images []*v1alpha.Image
+	for _, instances := range groupImagesByManifestList(matched) {
+		if expand {
+			for _, image := range instances {
+				image.ManifestList = manifestListSiblings(image, instances)
+				if !request.Detail {
+					image.Manifest = nil // Do not return image manifest in ListImages(detail=false).
+				}
+				images = append(images, image)
+			}
+			continue
 		}
-		images = append(images, image)
+
+		chosen := selectPlatformInstance(instances, nil)
+		chosen.ManifestList = manifestListSiblings(chosen, instances)
+		chosen.Manifest = nil // Do not return image manifest in ListImages(detail=false).
+		images = append(images, chosen)
 	}
 	return &v1alpha.ListImagesResponse{Images: images}, nil
 }
@@ -695,6 +1331,46 @@ func (s *v1alphaReadOnlyAPIServer) InspectImage(ctx context.Context, request *v1
 	if err != nil {
 		return nil, err
 	}
+
+	// An image with no Platform labels isn't part of a manifest list (see
+	// manifestListKey), so there's nothing else to look for.
+	if image.Platform == nil {
+		return &v1alpha.InspectImageResponse{Image: image}, nil
+	}
+
+	// Find the other platform instances sharing this image's (Name,
+	// Version), and pick the one matching request.Platform (defaulting to
+	// the already-resolved instance when no platform was requested).
+	aciInfos, err := s.store.GetAllACIInfos(nil, false)
+	if err != nil {
+		stderr.PrintE("failed to get all ACI infos", err)
+		return nil, err
+	}
+
+	key := manifestListKey(image)
+	var siblings []*v1alpha.Image
+	for _, aciInfo := range aciInfos {
+		other, err := aciInfoToV1AlphaAPIImage(s.store, aciInfo)
+		if err != nil || manifestListKey(other) != key {
+			continue
+		}
+		siblings = append(siblings, other)
+	}
+
+	if len(siblings) == 0 {
+		// Every re-conversion above failed (e.g. a concurrent image removal
+		// raced us) or the image otherwise turned up alone; fall back to the
+		// instance we already resolved rather than indexing an empty slice.
+		siblings = []*v1alpha.Image{image}
+	}
+
+	if request.Platform != nil {
+		if selected := selectPlatformInstance(siblings, request.Platform); selected != nil {
+			image = selected
+		}
+	}
+	image.ManifestList = manifestListSiblings(image, siblings)
+
 	return &v1alpha.InspectImageResponse{Image: image}, nil
 }
 
@@ -713,16 +1389,308 @@ func (sw LogsStreamWriter) Write(b []byte) (int, error) {
 		}
 	}
 
-	if err := sw.stream.Send(&v1alpha.GetLogsResponse{Lines: lines}); err != nil {
+	entries := make([]*v1alpha.LogEntry, 0, len(lines))
+	for _, line := range lines {
+		entries = append(entries, &v1alpha.LogEntry{Message: line})
+	}
+
+	if err := sw.stream.Send(&v1alpha.GetLogsResponse{Entries: entries}); err != nil {
 		return 0, err
 	}
 	return len(b), nil
 }
 
+// GetLogs streams a pod's (optionally a single app's) logs, honoring
+// Follow/TailLines/SinceTime/UntilTime/Grep. When the pod's stage1 is
+// systemd-based its journal directory is tailed directly via sd_journal so
+// callers get structured LogEntry records; other stage1s fall back to plain
+// file tailing through constrainedGetLogs.
 func (s *v1alphaReadOnlyAPIServer) GetLogs(request *v1alpha.GetLogsRequest, stream v1alpha.PublicAPI_GetLogsServer) error {
-	return s.constrainedGetLogs(request, stream)
+	uuid, err := types.NewUUID(request.Id)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("invalid pod id %q", request.Id), err)
+		return err
+	}
+
+	p, err := getPod(uuid)
+	if err != nil {
+		stderr.PrintE(fmt.Sprintf("failed to get pod %q", request.Id), err)
+		return err
+	}
+	defer p.Close()
+
+	journalDir, err := p.getJournalDir()
+	if err != nil {
+		// Not a systemd-journal-backed stage1; fall back to the legacy
+		// file-tailing implementation.
+		return s.constrainedGetLogs(request, stream)
+	}
+
+	return streamJournalLogs(p, journalDir, request, stream)
+}
+
+// exceedsUntilTime reports whether a journal entry timestamped tsUsec
+// (microseconds since the epoch, as sdjournal reports it) falls after
+// untilTime (Unix seconds, matching GetLogsRequest.UntilTime; 0 means
+// unbounded).
+func exceedsUntilTime(tsUsec uint64, untilTime int64) bool {
+	return untilTime > 0 && int64(tsUsec/uint64(time.Second/time.Microsecond)) > untilTime
+}
+
+// matchesGrep reports whether message should be kept given request's Grep
+// substring filter; an empty grep keeps everything.
+func matchesGrep(message, grep string) bool {
+	return grep == "" || strings.Contains(message, grep)
 }
 
+// streamJournalLogs tails the pod's private journal directory, applying
+// _SYSTEMD_UNIT=<app>.service and time-window matches before handing each
+// entry to the caller as a structured v1alpha.LogEntry.
+func streamJournalLogs(p *pod, journalDir string, request *v1alpha.GetLogsRequest, stream v1alpha.PublicAPI_GetLogsServer) error {
+	j, err := sdjournal.NewJournalFromDir(journalDir)
+	if err != nil {
+		return fmt.Errorf("failed to open journal directory %q: %v", journalDir, err)
+	}
+	defer j.Close()
+
+	if request.AppName != "" {
+		if err := j.AddMatch("_SYSTEMD_UNIT=" + request.AppName + ".service"); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case request.SinceTime > 0:
+		if err := j.SeekRealtimeUsec(uint64(request.SinceTime) * uint64(time.Second/time.Microsecond)); err != nil {
+			return err
+		}
+	case request.TailLines > 0:
+		if err := j.SeekTail(); err != nil {
+			return err
+		}
+		if _, err := j.PreviousSkip(uint64(request.TailLines)); err != nil {
+			return err
+		}
+	default:
+		if err := j.SeekHead(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		default:
+		}
+
+		n, err := j.Next()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			if !request.Follow {
+				return nil
+			}
+			j.Wait(time.Second)
+			continue
+		}
+
+		entry, err := j.GetEntry()
+		if err != nil {
+			continue
+		}
+
+		tsUsec := entry.RealtimeTimestamp
+		if exceedsUntilTime(tsUsec, request.UntilTime) {
+			return nil
+		}
+
+		message := entry.Fields["MESSAGE"]
+		if !matchesGrep(message, request.Grep) {
+			continue
+		}
+
+		priority, _ := strconv.Atoi(entry.Fields["PRIORITY"])
+		logEntry := &v1alpha.LogEntry{
+			Timestamp: int64(tsUsec) * int64(time.Microsecond),
+			Priority:  int32(priority),
+			AppName:   strings.TrimSuffix(entry.Fields["_SYSTEMD_UNIT"], ".service"),
+			PodId:     p.uuid.String(),
+			Message:   message,
+			Fields:    entry.Fields,
+		}
+
+		if err := stream.Send(&v1alpha.GetLogsResponse{Entries: []*v1alpha.LogEntry{logEntry}}); err != nil {
+			return err
+		}
+	}
+}
+
+// satisfiesEventFilter returns true if the event satisfies the filter.
+// The event, filter must not be nil.
+func satisfiesEventFilter(event v1alpha.Event, filter v1alpha.EventFilter) bool {
+	// Filter according to the event types.
+	if len(filter.Types) > 0 {
+		found := false
+		for _, t := range filter.Types {
+			if event.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Filter according to the pod/app/image IDs.
+	if len(filter.Ids) > 0 {
+		s := set.NewString(filter.Ids...)
+		if !s.Has(event.Id) {
+			return false
+		}
+	}
+
+	// Filter according to the app names.
+	if len(filter.Names) > 0 {
+		s := set.NewString(filter.Names...)
+		if !s.Has(event.Name) {
+			return false
+		}
+	}
+
+	// Filter according to the time window.
+	if filter.SinceTime > 0 && event.Time < filter.SinceTime {
+		return false
+	}
+	if filter.UntilTime > 0 && event.Time > filter.UntilTime {
+		return false
+	}
+
+	return true
+}
+
+// satisfiesAnyEventFilters returns true if any of the filter conditions is
+// satisfied by the event, or there's no filters.
+func satisfiesAnyEventFilters(event *v1alpha.Event, filters []*v1alpha.EventFilter) bool {
+	// No filters, return true directly.
+	if len(filters) == 0 {
+		return true
+	}
+
+	for _, filter := range filters {
+		if satisfiesEventFilter(*event, *filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventTypeToV1Alpha maps the internal events.Type to the corresponding
+// v1alpha.EventType enum value.
+func eventTypeToV1Alpha(t events.Type) v1alpha.EventType {
+	switch t {
+	case events.TypePodCreated:
+		return v1alpha.EventType_EVENT_TYPE_POD_CREATED
+	case events.TypePodPrepared:
+		return v1alpha.EventType_EVENT_TYPE_POD_PREPARED
+	case events.TypePodStarted:
+		return v1alpha.EventType_EVENT_TYPE_POD_STARTED
+	case events.TypePodExited:
+		return v1alpha.EventType_EVENT_TYPE_POD_EXITED
+	case events.TypePodGarbage:
+		return v1alpha.EventType_EVENT_TYPE_POD_GARBAGE_COLLECTED
+	case events.TypeAppStarted:
+		return v1alpha.EventType_EVENT_TYPE_APP_STARTED
+	case events.TypeAppExited:
+		return v1alpha.EventType_EVENT_TYPE_APP_EXITED
+	case events.TypeImageImported:
+		return v1alpha.EventType_EVENT_TYPE_IMAGE_IMPORTED
+	case events.TypeImageRemoved:
+		return v1alpha.EventType_EVENT_TYPE_IMAGE_REMOVED
+	default:
+		return v1alpha.EventType_EVENT_TYPE_UNDEFINED
+	}
+}
+
+// eventToV1Alpha converts an internal events.Event into the wire format
+// returned by ListenEvents.
+func eventToV1Alpha(ev events.Event) *v1alpha.Event {
+	id := ev.PodUUID
+	if ev.ImageID != "" {
+		id = ev.ImageID
+	}
+	return &v1alpha.Event{
+		Type:    eventTypeToV1Alpha(ev.Type),
+		Id:      id,
+		Name:    ev.AppName,
+		Time:    ev.Time.UnixNano(),
+		Message: ev.Message,
+	}
+}
+
+// earliestSinceTime returns the earliest SinceTime (a nanosecond Unix
+// timestamp, like v1alpha.Event.Time) across filters, for seeking the
+// journal/ring buffer back far enough to satisfy all of them. Since the
+// filters are combined with 'OR', a filter with no SinceTime bound (or no
+// filters at all) means history can't be excluded at all, so it returns the
+// zero Time in that case.
+func earliestSinceTime(filters []*v1alpha.EventFilter) time.Time {
+	if len(filters) == 0 {
+		return time.Time{}
+	}
+
+	var earliest time.Time
+	for _, filter := range filters {
+		if filter.SinceTime <= 0 {
+			return time.Time{}
+		}
+		t := time.Unix(0, filter.SinceTime)
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+	return earliest
+}
+
+// ListenEvents streams pod/app/image lifecycle events to the caller,
+// filtering on request.Filters the same way ListPods/ListImages combine
+// their filters with 'OR'. Events are tailed from the systemd journal when
+// available, falling back to the on-disk ring buffer otherwise; see the
+// events package for details. Playback starts from the earliest SinceTime
+// across request.Filters so a caller asking for history actually gets it,
+// instead of only events emitted after the call started.
 func (s *v1alphaReadOnlyAPIServer) ListenEvents(request *v1alpha.ListenEventsRequest, server v1alpha.PublicAPI_ListenEventsServer) error {
-	return fmt.Errorf("not implemented yet")
-}
\ No newline at end of file
+	stop := make(chan struct{})
+	go func() {
+		<-server.Context().Done()
+		close(stop)
+	}()
+
+	since := earliestSinceTime(request.Filters)
+	stream, errc := events.Follow(getDataDir(), since, events.Filter{}, stop)
+	for {
+		select {
+		case ev, ok := <-stream:
+			if !ok {
+				return nil
+			}
+			v1ev := eventToV1Alpha(ev)
+			if !satisfiesAnyEventFilters(v1ev, request.Filters) {
+				continue
+			}
+			if err := server.Send(&v1alpha.ListenEventsResponse{Events: []*v1alpha.Event{v1ev}}); err != nil {
+				stderr.PrintE("failed to send event", err)
+				return err
+			}
+		case err := <-errc:
+			if err != nil {
+				stderr.PrintE("failed to follow events", err)
+				return err
+			}
+		case <-server.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/coreos/rkt/api/v1alpha"
+)
+
+func TestSatisfiesPlatform(t *testing.T) {
+	linuxAmd64 := &v1alpha.Platform{Os: "linux", Arch: "amd64"}
+
+	tests := []struct {
+		name     string
+		p        *v1alpha.Platform
+		filter   *v1alpha.Platform
+		expected bool
+	}{
+		{"nil filter always matches", linuxAmd64, nil, true},
+		{"nil platform fails a real filter", nil, linuxAmd64, false},
+		{"exact match", linuxAmd64, &v1alpha.Platform{Os: "linux", Arch: "amd64"}, true},
+		{"arch mismatch", linuxAmd64, &v1alpha.Platform{Os: "linux", Arch: "arm64"}, false},
+		{"partial filter matches on the fields it sets", linuxAmd64, &v1alpha.Platform{Os: "linux"}, true},
+		{"variant must match when requested", linuxAmd64, &v1alpha.Platform{Variant: "v7"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := satisfiesPlatform(tt.p, tt.filter); got != tt.expected {
+				t.Errorf("satisfiesPlatform(%+v, %+v) = %v, want %v", tt.p, tt.filter, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGroupImagesByManifestList(t *testing.T) {
+	images := []*v1alpha.Image{
+		{Id: "sha512-a", Name: "example.com/app", Version: "1.0.0"},
+		{Id: "sha512-b", Name: "example.com/app", Version: "2.0.0"},
+		{Id: "sha512-c", Name: "example.com/multiarch", Version: "1.0.0", Platform: &v1alpha.Platform{Os: "linux", Arch: "amd64"}},
+		{Id: "sha512-d", Name: "example.com/multiarch", Version: "1.0.0", Platform: &v1alpha.Platform{Os: "linux", Arch: "arm64"}},
+	}
+
+	groups := groupImagesByManifestList(images)
+
+	// Same name, different versions, no Platform set: must stay distinct so
+	// plain 'rkt image list' doesn't collapse unrelated versions.
+	if len(groups["id:sha512-a"]) != 1 || len(groups["id:sha512-b"]) != 1 {
+		t.Errorf("versions of %q without a Platform were grouped together: %v", "example.com/app", groups)
+	}
+
+	// Same name+version, Platform set on both: genuine manifest-list
+	// siblings, must be grouped together.
+	key := "name-version:example.com/multiarch@1.0.0"
+	if len(groups[key]) != 2 {
+		t.Errorf("manifest-list siblings for %q were not grouped: got %d, want 2", key, len(groups[key]))
+	}
+}
+
+func TestSelectPlatformInstance(t *testing.T) {
+	if got := selectPlatformInstance(nil, nil); got != nil {
+		t.Errorf("selectPlatformInstance(nil, nil) = %v, want nil", got)
+	}
+
+	amd64 := &v1alpha.Image{Id: "sha512-a", Platform: &v1alpha.Platform{Os: "linux", Arch: "amd64"}}
+	arm64 := &v1alpha.Image{Id: "sha512-b", Platform: &v1alpha.Platform{Os: "linux", Arch: "arm64"}}
+	instances := []*v1alpha.Image{amd64, arm64}
+
+	if got := selectPlatformInstance(instances, &v1alpha.Platform{Arch: "arm64"}); got != arm64 {
+		t.Errorf("selectPlatformInstance did not honor an explicit platform request, got %v", got)
+	}
+
+	if got := selectPlatformInstance(instances, &v1alpha.Platform{Arch: "mips"}); got == nil {
+		t.Errorf("selectPlatformInstance with no match should still fall back, got nil")
+	}
+}
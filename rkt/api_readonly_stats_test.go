@@ -0,0 +1,105 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCpuPercent(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second uint64
+		elapsed       float64
+		wantZero      bool
+	}{
+		{"zero elapsed", 0, 1000, 0, true},
+		{"negative elapsed", 0, 1000, -1, true},
+		{"counter went backwards", 1000, 500, 1, true},
+		{"no usage", 1000, 1000, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cpuPercent(tt.first, tt.second, tt.elapsed)
+			if tt.wantZero && got != 0 {
+				t.Errorf("cpuPercent(%d, %d, %v) = %v, want 0", tt.first, tt.second, tt.elapsed, got)
+			}
+		})
+	}
+
+	if got := cpuPercent(0, uint64(time.Second), 1); got <= 0 {
+		t.Errorf("cpuPercent with one CPU-second of usage over one second = %v, want > 0", got)
+	}
+}
+
+func TestNetworkStatsDelta(t *testing.T) {
+	first := map[string]netDevStats{
+		"eth0": {rxBytes: 100, txBytes: 50},
+	}
+	second := map[string]netDevStats{
+		"eth0": {rxBytes: 200, txBytes: 150},
+	}
+
+	if out := networkStatsDelta(first, second, 0); out != nil {
+		t.Errorf("networkStatsDelta with elapsed=0 = %v, want nil", out)
+	}
+
+	out := networkStatsDelta(first, second, 2)
+	if len(out) != 1 {
+		t.Fatalf("networkStatsDelta returned %d entries, want 1", len(out))
+	}
+	if out[0].Interface != "eth0" || out[0].RxBytesPerSecond != 50 || out[0].TxBytesPerSecond != 50 {
+		t.Errorf("networkStatsDelta = %+v, want rx=50 tx=50 for eth0", out[0])
+	}
+
+	// A counter reset (e.g. interface recreated) must not underflow.
+	reset := map[string]netDevStats{"eth0": {rxBytes: 10, txBytes: 10}}
+	out = networkStatsDelta(first, reset, 2)
+	if out[0].RxBytesPerSecond != 0 || out[0].TxBytesPerSecond != 0 {
+		t.Errorf("networkStatsDelta across a counter reset = %+v, want 0/0", out[0])
+	}
+}
+
+func TestParseNetDevStatsShortRead(t *testing.T) {
+	// A torn-down netns can leave /proc/<pid>/net/dev with fewer than the
+	// two header lines; parseNetDevStats must report no interfaces instead
+	// of panicking on lines[2:].
+	if stats := parseNetDevStats("Inter-|   Receive\n"); len(stats) != 0 {
+		t.Errorf("parseNetDevStats on a header-only read = %v, want empty", stats)
+	}
+	if stats := parseNetDevStats(""); len(stats) != 0 {
+		t.Errorf("parseNetDevStats on an empty read = %v, want empty", stats)
+	}
+}
+
+func TestParseNetDevStats(t *testing.T) {
+	data := "Inter-|   Receive                                                |  Transmit\n" +
+		" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n" +
+		"    lo:    1000       1    0    0    0     0          0         0     1000       1    0    0    0     0       0          0\n" +
+		"  eth0:    2000       2    0    0    0     0          0         0     3000       3    0    0    0     0       0          0\n"
+
+	stats := parseNetDevStats(data)
+	if _, ok := stats["lo"]; ok {
+		t.Errorf("parseNetDevStats included the loopback interface: %v", stats)
+	}
+	eth0, ok := stats["eth0"]
+	if !ok {
+		t.Fatalf("parseNetDevStats did not find eth0: %v", stats)
+	}
+	if eth0.rxBytes != 2000 || eth0.txBytes != 3000 {
+		t.Errorf("parseNetDevStats eth0 = %+v, want rx=2000 tx=3000", eth0)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/coreos/rkt/api/v1alpha"
+	"github.com/coreos/rkt/pkg/autoupdate"
+	"github.com/coreos/rkt/store/imagestore"
+	"golang.org/x/net/context"
+)
+
+// v1alphaAPIServer is the read-write sibling of v1alphaReadOnlyAPIServer. It
+// embeds the read-only server so it gets ListPods/ListImages/GetLogs/etc for
+// free, and adds the mutating operations (image GC, disk accounting,
+// auto-update) that shouldn't be reachable from a read-only API socket.
+type v1alphaAPIServer struct {
+	*v1alphaReadOnlyAPIServer
+
+	autoUpdateMu    sync.Mutex
+	autoUpdateRecon *autoupdate.Reconciler
+	lastAutoUpdates map[string]autoupdate.PodUpdate
+	stopAutoUpdate  chan struct{}
+}
+
+func newV1alphaAPIServer(s *imagestore.Store) (*v1alphaAPIServer, error) {
+	ro, err := newV1alphaReadOnlyAPIServer(s)
+	if err != nil {
+		return nil, err
+	}
+	server := &v1alphaAPIServer{
+		v1alphaReadOnlyAPIServer: ro,
+		lastAutoUpdates:          make(map[string]autoupdate.PodUpdate),
+		stopAutoUpdate:           make(chan struct{}),
+	}
+	server.autoUpdateRecon = autoupdate.NewReconciler(
+		apiPodLister{server: server},
+		registryImageResolver{store: s, recorder: ro.recorder},
+		execPodReplacer{},
+	)
+	server.autoUpdateRecon.OnUpdate = server.recordAutoUpdates
+	go server.autoUpdateRecon.Run(server.stopAutoUpdate)
+	return server, nil
+}
+
+// Close stops the background auto-update reconciler. It's safe to call at
+// most once, mirroring the rest of this package's one-shot teardown helpers.
+func (s *v1alphaAPIServer) Close() {
+	close(s.stopAutoUpdate)
+}
+
+// referencedBlobKeys returns the set of image blob keys referenced by any
+// non-garbage pod manifest, resolved to their full store key so they can be
+// compared against imagestore.ACIInfo.BlobKey.
+func referencedBlobKeys(store *imagestore.Store) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+
+	if err := walkPods(includeMostDirs, func(p *pod) {
+		manifest, _, err := getPodManifest(p)
+		if err != nil {
+			return
+		}
+		for _, app := range getApplist(manifest) {
+			key, err := store.ResolveKey(app.Image.Id)
+			if err != nil {
+				// The image may have already been removed; nothing to keep
+				// it alive for.
+				continue
+			}
+			referenced[key] = true
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	return referenced, nil
+}
+
+// PruneImages removes ACIs (and their tree store entries) that are not
+// referenced by any pod manifest and that match request's filters.
+func (s *v1alphaAPIServer) PruneImages(ctx context.Context, request *v1alpha.PruneImagesRequest) (*v1alpha.PruneImagesResponse, error) {
+	aciInfos, err := s.store.GetAllACIInfos(nil, false)
+	if err != nil {
+		stderr.PrintE("failed to get all ACI infos", err)
+		return nil, err
+	}
+
+	referenced, err := referencedBlobKeys(s.store)
+	if err != nil {
+		stderr.PrintE("failed to determine referenced images", err)
+		return nil, err
+	}
+
+	var pruned []*v1alpha.PrunedImage
+	for _, aciInfo := range aciInfos {
+		if referenced[aciInfo.BlobKey] {
+			continue
+		}
+
+		image, err := aciInfoToV1AlphaAPIImage(s.store, aciInfo)
+		if err != nil {
+			stderr.PrintE(fmt.Sprintf("failed to load image %q, skipping", aciInfo.BlobKey), err)
+			continue
+		}
+
+		if !matchesPruneFilter(image, aciInfo, request) {
+			continue
+		}
+
+		reclaimed := aciInfo.Size + aciInfo.TreeStoreSize
+		if !request.DryRun {
+			if err := imagestore.RemoveACIAndEmit(s.store, s.recorder, aciInfo); err != nil {
+				stderr.PrintE(fmt.Sprintf("failed to remove ACI %q", aciInfo.BlobKey), err)
+				continue
+			}
+		}
+
+		pruned = append(pruned, &v1alpha.PrunedImage{
+			Id:             aciInfo.BlobKey,
+			Name:           aciInfo.Name,
+			ReclaimedBytes: reclaimed,
+		})
+	}
+
+	return &v1alpha.PruneImagesResponse{Pruned: pruned, DryRun: request.DryRun}, nil
+}
+
+// matchesPruneFilter reports whether image/aciInfo pass request's tagging,
+// age, label and annotation filters. It doesn't consider whether the image
+// is referenced by a live pod manifest; that check stays in PruneImages
+// itself, since it needs the caller's already-computed referenced set.
+func matchesPruneFilter(image *v1alpha.Image, aciInfo *imagestore.ACIInfo, request *v1alpha.PruneImagesRequest) bool {
+	if request.UntaggedOnly {
+		if _, tagged := findInKeyValues(image.Labels, "version"); tagged {
+			return false
+		}
+	}
+
+	if request.OlderThanSeconds > 0 {
+		cutoff := time.Now().Add(-time.Duration(request.OlderThanSeconds) * time.Second)
+		if aciInfo.ImportTime.After(cutoff) {
+			return false
+		}
+	}
+
+	if len(request.Labels) > 0 && !containsAllKeyValues(image.Labels, request.Labels) {
+		return false
+	}
+	if len(request.Annotations) > 0 && !containsAllKeyValues(image.Annotations, request.Annotations) {
+		return false
+	}
+
+	return true
+}
+
+// duDir returns the total size, in bytes, of all regular files under dir.
+func duDir(dir string) (int64, error) {
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Pods can disappear (e.g. GC'd) while we're walking them; skip
+			// rather than fail the whole report.
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetDiskUsage reports how much space images, tree stores and per-pod
+// directories (stage1/stage2/logs) are using, along with how many of those
+// bytes are reclaimable via PruneImages/gc.
+func (s *v1alphaAPIServer) GetDiskUsage(ctx context.Context, request *v1alpha.GetDiskUsageRequest) (*v1alpha.GetDiskUsageResponse, error) {
+	aciInfos, err := s.store.GetAllACIInfos(nil, false)
+	if err != nil {
+		stderr.PrintE("failed to get all ACI infos", err)
+		return nil, err
+	}
+
+	referenced, err := referencedBlobKeys(s.store)
+	if err != nil {
+		stderr.PrintE("failed to determine referenced images", err)
+		return nil, err
+	}
+
+	report := &v1alpha.GetDiskUsageResponse{DryRun: request.DryRun}
+
+	var imagesTotal, treeStoreTotal, reclaimableTotal int64
+	for _, aciInfo := range aciInfos {
+		imagesTotal += aciInfo.Size
+		treeStoreTotal += aciInfo.TreeStoreSize
+
+		item := &v1alpha.DiskUsageItem{
+			Id:    aciInfo.BlobKey,
+			Name:  aciInfo.Name,
+			Bytes: aciInfo.Size + aciInfo.TreeStoreSize,
+		}
+		if !referenced[aciInfo.BlobKey] {
+			item.ReclaimableBytes = item.Bytes
+			reclaimableTotal += item.Bytes
+		}
+		report.Images = append(report.Images, item)
+	}
+	report.ImagesBytes = imagesTotal
+	report.TreeStoreBytes = treeStoreTotal
+	report.ReclaimableBytes = reclaimableTotal
+
+	if err := walkPods(includeMostDirs, func(p *pod) {
+		item, err := podDiskUsage(p)
+		if err != nil {
+			stderr.PrintE(fmt.Sprintf("failed to compute disk usage for pod %q", p.uuid), err)
+			return
+		}
+		report.Pods = append(report.Pods, item)
+		report.PodsBytes += item.Bytes
+	}); err != nil {
+		stderr.PrintE("failed to walk pods for disk usage", err)
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// podDiskUsage breaks a pod's total directory size down into the
+// stage1 rootfs (the stage1 image itself), the stage2 rootfses (one per
+// app), and the pod's private journal, so callers can tell what they'd
+// actually reclaim by GC'ing a stage2 vs. the whole pod.
+func podDiskUsage(p *pod) (*v1alpha.DiskUsageItem, error) {
+	total, err := duDir(p.path())
+	if err != nil {
+		return nil, err
+	}
+
+	var stage2Total int64
+	if manifest, _, err := getPodManifest(p); err == nil {
+		for _, app := range getApplist(manifest) {
+			appRootfs := filepath.Join(p.path(), "stage1", "rootfs", "opt", "stage2", app.Name, "rootfs")
+			if size, err := duDir(appRootfs); err == nil {
+				stage2Total += size
+			}
+		}
+	}
+
+	var logsTotal int64
+	if journalDir, err := p.getJournalDir(); err == nil {
+		if size, err := duDir(journalDir); err == nil {
+			logsTotal = size
+		}
+	}
+
+	return &v1alpha.DiskUsageItem{
+		Id:          p.uuid.String(),
+		Bytes:       total,
+		Stage1Bytes: diskUsageStage1Bytes(total, stage2Total, logsTotal),
+		Stage2Bytes: stage2Total,
+		LogsBytes:   logsTotal,
+	}, nil
+}
+
+// diskUsageStage1Bytes returns the portion of a pod's total directory size
+// attributable to stage1 itself (total minus the stage2 rootfses and the
+// journal), clamped to zero since best-effort walks of a live,
+// possibly-changing pod directory don't perfectly reconcile.
+func diskUsageStage1Bytes(total, stage2Total, logsTotal int64) int64 {
+	stage1Total := total - stage2Total - logsTotal
+	if stage1Total < 0 {
+		return 0
+	}
+	return stage1Total
+}
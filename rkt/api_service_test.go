@@ -0,0 +1,114 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coreos/rkt/api/v1alpha"
+	"github.com/coreos/rkt/store/imagestore"
+)
+
+func TestDuDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rkt-dudir-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(sub, "b"), make([]byte, 50), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := duDir(dir)
+	if err != nil {
+		t.Fatalf("duDir: %v", err)
+	}
+	if got != 150 {
+		t.Errorf("duDir(%q) = %d, want 150 (100 + 50 bytes across subdirectories)", dir, got)
+	}
+}
+
+func TestDuDirMissing(t *testing.T) {
+	if got, err := duDir("/no/such/rkt-dudir-test-path"); err != nil || got != 0 {
+		t.Errorf("duDir on a missing directory = (%d, %v), want (0, nil) since a gone pod dir must not fail the whole report", got, err)
+	}
+}
+
+func TestMatchesPruneFilter(t *testing.T) {
+	untagged := &v1alpha.Image{Labels: nil}
+	tagged := &v1alpha.Image{Labels: []*v1alpha.KeyValue{{Key: "version", Value: "1.0.0"}}}
+	labeled := &v1alpha.Image{Labels: []*v1alpha.KeyValue{{Key: "channel", Value: "stable"}}}
+	annotated := &v1alpha.Image{Annotations: []*v1alpha.KeyValue{{Key: "team", Value: "infra"}}}
+
+	old := &imagestore.ACIInfo{ImportTime: time.Now().Add(-2 * time.Hour)}
+	recent := &imagestore.ACIInfo{ImportTime: time.Now()}
+
+	tests := []struct {
+		name     string
+		image    *v1alpha.Image
+		aciInfo  *imagestore.ACIInfo
+		request  *v1alpha.PruneImagesRequest
+		expected bool
+	}{
+		{"no filters matches everything", untagged, recent, &v1alpha.PruneImagesRequest{}, true},
+		{"untagged-only keeps an untagged image", untagged, recent, &v1alpha.PruneImagesRequest{UntaggedOnly: true}, true},
+		{"untagged-only excludes a tagged image", tagged, recent, &v1alpha.PruneImagesRequest{UntaggedOnly: true}, false},
+		{"older-than excludes a recent import", untagged, recent, &v1alpha.PruneImagesRequest{OlderThanSeconds: 3600}, false},
+		{"older-than keeps an old import", untagged, old, &v1alpha.PruneImagesRequest{OlderThanSeconds: 3600}, true},
+		{"label filter requires a match", labeled, recent, &v1alpha.PruneImagesRequest{Labels: []*v1alpha.KeyValue{{Key: "channel", Value: "stable"}}}, true},
+		{"label filter excludes a mismatch", labeled, recent, &v1alpha.PruneImagesRequest{Labels: []*v1alpha.KeyValue{{Key: "channel", Value: "alpha"}}}, false},
+		{"annotation filter requires a match", annotated, recent, &v1alpha.PruneImagesRequest{Annotations: []*v1alpha.KeyValue{{Key: "team", Value: "infra"}}}, true},
+		{"annotation filter excludes a missing key", untagged, recent, &v1alpha.PruneImagesRequest{Annotations: []*v1alpha.KeyValue{{Key: "team", Value: "infra"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesPruneFilter(tt.image, tt.aciInfo, tt.request); got != tt.expected {
+				t.Errorf("matchesPruneFilter(%+v, %+v, %+v) = %v, want %v", tt.image, tt.aciInfo, tt.request, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiskUsageStage1Bytes(t *testing.T) {
+	tests := []struct {
+		name                          string
+		total, stage2Total, logsTotal int64
+		expected                      int64
+	}{
+		{"splits total across categories", 300, 100, 50, 150},
+		{"no stage2 or logs", 100, 0, 0, 100},
+		{"clamps a negative remainder to zero", 100, 80, 40, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diskUsageStage1Bytes(tt.total, tt.stage2Total, tt.logsTotal); got != tt.expected {
+				t.Errorf("diskUsageStage1Bytes(%d, %d, %d) = %d, want %d", tt.total, tt.stage2Total, tt.logsTotal, got, tt.expected)
+			}
+		})
+	}
+}
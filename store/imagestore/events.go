@@ -0,0 +1,48 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagestore
+
+import "github.com/coreos/rkt/events"
+
+// EmitImported records that name was imported into the store as blobKey.
+// Callers that fetch/import ACIs should call this right after a successful
+// WriteACI so image-import shows up in 'rkt events'/journald alongside pod
+// lifecycle events.
+func EmitImported(rec *events.Recorder, blobKey, name string) {
+	if rec == nil {
+		return
+	}
+	rec.Emit(events.Event{Type: events.TypeImageImported, ImageID: blobKey, Message: name})
+}
+
+// RemoveACIAndEmit removes aciInfo's blob from the store and, if that
+// succeeds, records an image-removed event. rec may be nil (e.g. in tests),
+// in which case no event is recorded.
+func RemoveACIAndEmit(s *Store, rec *events.Recorder, aciInfo *ACIInfo) error {
+	if err := s.RemoveACI(aciInfo.BlobKey); err != nil {
+		return err
+	}
+	emit(rec, events.TypeImageRemoved, aciInfo.BlobKey)
+	return nil
+}
+
+func emit(rec *events.Recorder, t events.Type, blobKey string) {
+	if rec == nil {
+		return
+	}
+	if err := rec.Emit(events.Event{Type: t, ImageID: blobKey}); err != nil {
+		return
+	}
+}